@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azdatalake/filesystem"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azdatalake/sas"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azdatalake/service"
+)
+
+//--------------------------------------Location: ADLS Gen2 (dfs) --------------------------------------------------------
+
+const blobEndpointSuffix = ".blob.core.windows.net"
+const dfsEndpointSuffix = ".dfs.core.windows.net"
+
+type locType string
+
+const (
+	locTypeBlob locType = ""
+	locTypeDFS  locType = "dfs"
+)
+
+// GetURLs returns both the blob-endpoint and dfs-endpoint forms of a storage URL, since HNS
+// accounts are reachable through either hostname and callers need whichever form they were handed.
+func GetURLs(rawURL string) (blobURL string, dfsURL string) {
+	switch {
+	case strings.Contains(rawURL, blobEndpointSuffix):
+		return rawURL, strings.Replace(rawURL, blobEndpointSuffix, dfsEndpointSuffix, 1)
+	case strings.Contains(rawURL, dfsEndpointSuffix):
+		return strings.Replace(rawURL, dfsEndpointSuffix, blobEndpointSuffix, 1), rawURL
+	default:
+		return rawURL, rawURL
+	}
+}
+
+func getDFSServiceClient(accountType blobAccountType, options *service.ClientOptions) (*service.Client, error) {
+	accountName, err := getRequiredEnv(string(accountType) + AccountNameEnvVar)
+	if err != nil {
+		return nil, err
+	}
+	serviceURL := "https://" + accountName + dfsEndpointSuffix + "/"
+
+	if getAuthType(accountType) == authSharedKey {
+		accountKey, err := getRequiredEnv(string(accountType) + AccountKeyEnvVar)
+		if err != nil {
+			return nil, err
+		}
+		cred, err := service.NewSharedKeyCredential(accountName, accountKey)
+		if err != nil {
+			return nil, err
+		}
+		return service.NewClientWithSharedKeyCredential(serviceURL, cred, options)
+	}
+
+	tokenCred, err := getTokenCredential(accountType, getAuthType(accountType))
+	if err != nil {
+		return nil, err
+	}
+	return service.NewClient(serviceURL, tokenCred, options)
+}
+
+func createNewFilesystem(filesystemName string, svcClient *service.Client) (*filesystem.Client, error) {
+	fsClient := svcClient.NewFileSystemClient(filesystemName)
+	_, err := fsClient.Create(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return fsClient, nil
+}
+
+// getFilesystemSAS signs a filesystem SAS, using a user-delegation key when the account is on
+// token auth (MSI/SPN/CLI/default) since those accounts may have shared keys disabled entirely,
+// and a shared-key signature otherwise. It returns an error rather than exiting so a DFS location
+// on a token-auth account degrades the same way the blob path does.
+func getFilesystemSAS(accountType blobAccountType, svcClient *service.Client, fsClient *filesystem.Client, start time.Time, expiry time.Time) ([]string, error) {
+	perms := sas.FileSystemPermissions{Read: true, Add: true, Create: true, Write: true, Delete: true, List: true}
+	getSASURLOpts := &filesystem.GetSASURLOptions{StartTime: to.Ptr(start.UTC())}
+
+	var sasURL string
+	var err error
+	if getAuthType(accountType) == authSharedKey {
+		var accountName, accountKey string
+		if accountName, err = getRequiredEnv(string(accountType) + AccountNameEnvVar); err != nil {
+			return nil, err
+		}
+		if accountKey, err = getRequiredEnv(string(accountType) + AccountKeyEnvVar); err != nil {
+			return nil, err
+		}
+		var cred *service.SharedKeyCredential
+		if cred, err = service.NewSharedKeyCredential(accountName, accountKey); err != nil {
+			return nil, err
+		}
+		sasURL, err = fsClient.GetSASURL(perms, expiry.UTC(), getSASURLOpts, cred)
+	} else {
+		var udc *service.UserDelegationCredential
+		if udc, err = svcClient.GetUserDelegationCredential(context.Background(), service.KeyInfo{
+			Start:  to.Ptr(start.UTC().Format(sas.TimeFormat)),
+			Expiry: to.Ptr(expiry.UTC().Format(sas.TimeFormat)),
+		}, nil); err == nil {
+			sasURL, err = fsClient.GetSASURL(perms, expiry.UTC(), getSASURLOpts, udc)
+		}
+	}
+	if err != nil {
+		_, _ = fsClient.Delete(context.Background(), nil)
+		return nil, err
+	}
+	return []string{sasURL}, nil
+}
+
+func deleteFilesystem(accountType blobAccountType, filesystemName string) {
+	svcClient, err := getDFSServiceClient(accountType, nil)
+	if err != nil {
+		fmt.Printf("Failed to get dfs serviceClient due to error: %s\n", err.Error())
+		return
+	}
+
+	_, err = svcClient.NewFileSystemClient(filesystemName).Delete(context.Background(), nil)
+	if err != nil {
+		fmt.Printf("Failed to delete the filesystem %s due to error: %s\n", filesystemName, err.Error())
+	} else {
+		fmt.Printf("Successfully deleted filesystem: %s\n", filesystemName)
+	}
+}
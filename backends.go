@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+//--------------------------------------Location: Multi-cloud backends ---------------------------------------------------
+
+// BackendType picks which cloud (or the local filesystem) a location is materialized against.
+type BackendType string
+
+const (
+	BackendAzBlob BackendType = "azblob"
+	BackendAzDFS  BackendType = "azdfs" // azblob account accessed via the dfs (HNS) endpoint; recordJob/gcJobs-only marker, not a getLocationBackend case
+	BackendS3     BackendType = "s3"
+	BackendGCS    BackendType = "gcs"
+	BackendLocal  BackendType = "local"
+)
+
+// LocationBackend is the set of operations Symphony needs from a location regardless of which
+// cloud it lives in, so locB/locC/locD can mix azblob, S3, GCS, and local in any combination.
+type LocationBackend interface {
+	CreateContainer(name string) (string, error)
+	SignedURL(start time.Time, expiry time.Time, opts *sasOptions) (string, error)
+	// Bind attaches this backend to a container/bucket/path it did not create in this process -
+	// e.g. delLocB/delLocC tearing down what an earlier locB/locC invocation created - by parsing
+	// the name out of that location's signed URL, and returns the extracted name.
+	Bind(url string) string
+	Delete() error
+}
+
+// extractBackendFlag pulls a "--backend=<type>" flag out of the CLI arguments wherever it appears,
+// returning the selected backend (azblob when unset, to preserve existing behavior) and the rest.
+func extractBackendFlag(arguments []string) (BackendType, []string) {
+	backend := BackendAzBlob
+	remaining := make([]string, 0, len(arguments))
+	for _, a := range arguments {
+		if strings.HasPrefix(a, "--backend=") {
+			backend = BackendType(strings.TrimPrefix(a, "--backend="))
+			continue
+		}
+		remaining = append(remaining, a)
+	}
+	return backend, remaining
+}
+
+func getLocationBackend(backend BackendType, accountType blobAccountType) (LocationBackend, error) {
+	switch backend {
+	case BackendAzBlob, "":
+		return newAzBlobBackend(accountType)
+	case BackendS3:
+		return newS3Backend()
+	case BackendGCS:
+		return newGCSBackend()
+	case BackendLocal:
+		return newLocalBackend(), nil
+	default:
+		return nil, errors.New("unsupported backend: " + string(backend))
+	}
+}
+
+//--------------------------------------Backend: azblob -------------------------------------------------------------
+
+type azBlobBackend struct {
+	accountType blobAccountType
+	svcClient   azblob.ServiceClient
+	container   azblob.ContainerClient
+}
+
+func newAzBlobBackend(accountType blobAccountType) (*azBlobBackend, error) {
+	svcClient, err := getServiceClient(accountType, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &azBlobBackend{accountType: accountType, svcClient: svcClient}, nil
+}
+
+func (b *azBlobBackend) CreateContainer(name string) (string, error) {
+	client, err := createNewContainer(name, b.svcClient)
+	if err != nil {
+		return "", err
+	}
+	b.container = client
+	return name, nil
+}
+
+func (b *azBlobBackend) SignedURL(start time.Time, expiry time.Time, opts *sasOptions) (string, error) {
+	return getContainerSAS(b.accountType, b.svcClient, b.container, start, expiry, opts)[0], nil
+}
+
+func (b *azBlobBackend) Bind(url string) string {
+	name := getContainerName(url)
+	b.container = b.svcClient.NewContainerClient(name)
+	return name
+}
+
+func (b *azBlobBackend) Delete() error {
+	_, err := b.container.Delete(context.Background(), nil)
+	return err
+}
+
+//--------------------------------------Backend: S3 ------------------------------------------------------------------
+
+type s3Backend struct {
+	client *s3.Client
+	bucket string
+}
+
+func newS3Backend() (*s3Backend, error) {
+	region, err := getRequiredEnv("AWS_REGION")
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+	return &s3Backend{client: s3.NewFromConfig(cfg)}, nil
+}
+
+func (b *s3Backend) CreateContainer(name string) (string, error) {
+	if _, err := b.client.CreateBucket(context.Background(), &s3.CreateBucketInput{Bucket: aws.String(name)}); err != nil {
+		return "", err
+	}
+	b.bucket = name
+	return name, nil
+}
+
+// SignedURL returns the bucket's plain virtual-hosted URL rather than a presigned one: S3 presigned
+// URLs are scoped to a single object and a single action (GET or PUT), so they can't stand in for a
+// whole-bucket source or destination the way an Azure container SAS can. azcopy instead
+// authenticates S3 directly from AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY/AWS_REGION in the
+// environment, so `opts` must be enforced via the IAM policy attached to those credentials.
+func (b *s3Backend) SignedURL(_ time.Time, _ time.Time, _ *sasOptions) (string, error) {
+	return "https://" + b.bucket + ".s3.amazonaws.com", nil
+}
+
+// Bind extracts the bucket name out of the "https://<bucket>.s3.amazonaws.com" URL SignedURL
+// produced, so delLocB/delLocC can tear down a bucket this process didn't itself create.
+func (b *s3Backend) Bind(url string) string {
+	b.bucket = strings.TrimSuffix(strings.TrimPrefix(url, "https://"), ".s3.amazonaws.com")
+	return b.bucket
+}
+
+func (b *s3Backend) Delete() error {
+	_, err := b.client.DeleteBucket(context.Background(), &s3.DeleteBucketInput{Bucket: aws.String(b.bucket)})
+	return err
+}
+
+//--------------------------------------Backend: GCS -----------------------------------------------------------------
+
+type gcsBackend struct {
+	client    *storage.Client
+	projectID string
+	bucket    string
+}
+
+func newGCSBackend() (*gcsBackend, error) {
+	projectID, err := getRequiredEnv("GOOGLE_PROJECT_ID")
+	if err != nil {
+		return nil, err
+	}
+	client, err := storage.NewClient(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &gcsBackend{client: client, projectID: projectID}, nil
+}
+
+func (b *gcsBackend) CreateContainer(name string) (string, error) {
+	if err := b.client.Bucket(name).Create(context.Background(), b.projectID, nil); err != nil {
+		return "", err
+	}
+	b.bucket = name
+	return name, nil
+}
+
+// SignedURL returns the bucket's gs:// URL rather than a signed one, for the same reason as
+// s3Backend.SignedURL: a GCS signed URL is scoped to one object and one method, so it can't serve
+// as a whole-bucket source or destination. azcopy authenticates GCS from
+// GOOGLE_APPLICATION_CREDENTIALS in the environment, so `opts` must be enforced via IAM on that
+// credential rather than in the URL.
+func (b *gcsBackend) SignedURL(_ time.Time, _ time.Time, _ *sasOptions) (string, error) {
+	return "gs://" + b.bucket, nil
+}
+
+// Bind extracts the bucket name out of the "gs://<bucket>" URL SignedURL produced, so
+// delLocB/delLocC can tear down a bucket this process didn't itself create.
+func (b *gcsBackend) Bind(url string) string {
+	b.bucket = strings.TrimPrefix(url, "gs://")
+	return b.bucket
+}
+
+func (b *gcsBackend) Delete() error {
+	return b.client.Bucket(b.bucket).Delete(context.Background())
+}
+
+//--------------------------------------Backend: local ---------------------------------------------------------------
+
+type localBackend struct {
+	path string
+}
+
+func newLocalBackend() *localBackend {
+	return &localBackend{}
+}
+
+func (b *localBackend) CreateContainer(name string) (string, error) {
+	path := filepath.Join(os.TempDir(), name)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return "", err
+	}
+	b.path = path
+	return path, nil
+}
+
+func (b *localBackend) SignedURL(_ time.Time, _ time.Time, _ *sasOptions) (string, error) {
+	return b.path, nil
+}
+
+// Bind treats the URL as the path itself, since CreateContainer's "signed URL" for local is already
+// the absolute path - there's no separate name to parse back out of it.
+func (b *localBackend) Bind(url string) string {
+	b.path = url
+	return url
+}
+
+func (b *localBackend) Delete() error {
+	return os.RemoveAll(b.path)
+}
+
+//--------------------------------------Backend-agnostic locations --------------------------------------------------
+
+// createLocationBBackend is createLocationB generalized over LocationBackend, used whenever
+// --backend selects something other than azblob (the default, still handled by createLocationB).
+func createLocationBBackend(azcopyVersion string, localPath string, hours time.Duration, backend BackendType) {
+	b, err := getLocationBackend(backend, blobAccountDefault)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	data := make([][]string, 0)
+	data = append(data, []string{localPath})
+	if _, err := b.CreateContainer(generateContainerName()); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	signedURL, err := b.SignedURL(time.Now(), time.Now().Add(hours*time.Hour), &sasOptions{Permissions: "racwdl"})
+	if err != nil {
+		_ = b.Delete()
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	data = append(data, []string{signedURL})
+	WriteToFile("locationB"+azcopyVersion+".csv", data)
+}
+
+// createLocationCBackend creates the destination container on `backend`; the source is already a
+// signed URL (possibly from a different backend) produced by a prior locB/locC step.
+func createLocationCBackend(azcopyVersion string, sourceURL string, hours time.Duration, backend BackendType) {
+	data := make([][]string, 0)
+	data = append(data, []string{sourceURL})
+
+	b, err := getLocationBackend(backend, blobAccountSecondary)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if _, err := b.CreateContainer(generateContainerName()); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	signedURL, err := b.SignedURL(time.Now(), time.Now().Add(hours*time.Hour), &sasOptions{Permissions: "racwdl"})
+	if err != nil {
+		_ = b.Delete()
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	data = append(data, []string{signedURL})
+	WriteToFile("locationC"+azcopyVersion+".csv", data)
+}
+
+// createLocationDBackend passes the already-signed source URL straight through to the download
+// step; non-azblob backends sign once at creation time rather than being re-signed by name.
+func createLocationDBackend(azcopyVersion string, sourceURL string, localPath string) {
+	data := make([][]string, 0)
+	data = append(data, []string{sourceURL})
+	data = append(data, []string{localPath})
+	WriteToFile("locationD"+azcopyVersion+".csv", data)
+}
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,6 +22,14 @@ func max(a, b int) int {
 	return b
 }
 
+// argOrEmpty returns arguments[i] if present, or "" otherwise, for CLI flags that are optional.
+func argOrEmpty(arguments []string, i int) string {
+	if i < len(arguments) {
+		return arguments[i]
+	}
+	return ""
+}
+
 // getRequiredEnv gets an environment variable by name and returns an error if it is not found
 func getRequiredEnv(name string) (string, error) {
 	env, ok := os.LookupEnv(name)
@@ -77,15 +86,28 @@ func getGenericCredential(accountType blobAccountType) (*azblob.SharedKeyCredent
 }
 
 func getServiceClient(accountType blobAccountType, options *azblob.ClientOptions) (azblob.ServiceClient, error) {
-	cred, err := getGenericCredential(accountType)
+	auth := getAuthType(accountType)
+	if auth == authSharedKey {
+		cred, err := getGenericCredential(accountType)
+		if err != nil {
+			return azblob.ServiceClient{}, err
+		}
+
+		serviceURL, _ := url.Parse("https://" + cred.AccountName() + ".blob.core.windows.net/")
+		return azblob.NewServiceClientWithSharedKey(serviceURL.String(), cred, options)
+	}
+
+	accountName, err := getRequiredEnv(string(accountType) + AccountNameEnvVar)
+	if err != nil {
+		return azblob.ServiceClient{}, err
+	}
+	tokenCred, err := getTokenCredential(accountType, auth)
 	if err != nil {
 		return azblob.ServiceClient{}, err
 	}
 
-	serviceURL, _ := url.Parse("https://" + cred.AccountName() + ".blob.core.windows.net/")
-	serviceClient, err := azblob.NewServiceClientWithSharedKey(serviceURL.String(), cred, options)
-
-	return serviceClient, err
+	serviceURL, _ := url.Parse("https://" + accountName + ".blob.core.windows.net/")
+	return azblob.NewServiceClient(serviceURL.String(), tokenCred, options)
 }
 
 func createNewContainer(containerName string, serviceClient azblob.ServiceClient) (azblob.ContainerClient, error) {
@@ -99,15 +121,30 @@ func createNewContainer(containerName string, serviceClient azblob.ServiceClient
 	return containerClient, err
 }
 
-func getContainerSAS(accountType blobAccountType, client azblob.ContainerClient, start time.Time, expiry time.Time) []string {
-	credentials, err := getGenericCredential(accountType)
+func getContainerSAS(accountType blobAccountType, serviceClient azblob.ServiceClient, client azblob.ContainerClient, start time.Time, expiry time.Time, opts *sasOptions) []string {
 	urlParts := azblob.NewBlobURLParts(client.URL())
-	sas, err := azblob.BlobSASSignatureValues{
+	sasValues := opts.applyTo(azblob.BlobSASSignatureValues{
 		ContainerName: urlParts.ContainerName,
-		Permissions:   azblob.ContainerSASPermissions{Read: true, Add: true, Create: true, Write: true, Delete: true, List: true}.String(),
+		Permissions:   opts.containerPermissions().String(),
 		StartTime:     start.UTC(),
 		ExpiryTime:    expiry.UTC(),
-	}.NewSASQueryParameters(credentials)
+	})
+
+	var sas string
+	var err error
+	if auth := getAuthType(accountType); auth == authSharedKey {
+		var credentials *azblob.SharedKeyCredential
+		credentials, err = getGenericCredential(accountType)
+		if err == nil {
+			sas, err = sasValues.NewSASQueryParameters(credentials)
+		}
+	} else {
+		var udc *azblob.UserDelegationCredential
+		udc, err = getUserDelegationCredential(serviceClient, start, expiry)
+		if err == nil {
+			sas, err = sasValues.SignWithUserDelegation(udc)
+		}
+	}
 	if err != nil {
 		_, _ = client.Delete(context.Background(), nil)
 		fmt.Println(err)
@@ -154,7 +191,11 @@ func WriteToFile(path string, data [][]string) {
 	writer.Flush()
 }
 
-func createLocationB(azcopyVersion string, localPath string, hours time.Duration) {
+func createLocationB(azcopyVersion string, localPath string, hours time.Duration, lt locType, opts *sasOptions) {
+	if lt == locTypeDFS {
+		createLocationBDFS(azcopyVersion, localPath, hours)
+		return
+	}
 	svcClient, err := getServiceClient(blobAccountDefault, nil)
 	if err != nil {
 		fmt.Println(err)
@@ -162,16 +203,50 @@ func createLocationB(azcopyVersion string, localPath string, hours time.Duration
 	}
 	data := make([][]string, 0)
 	data = append(data, []string{localPath})
-	containerClient, err := createNewContainer(generateContainerName(), svcClient)
+	containerName := generateContainerName()
+	containerClient, err := createNewContainer(containerName, svcClient)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	data = append(data, getContainerSAS(blobAccountDefault, containerClient, time.Now(), time.Now().Add(hours*time.Hour)))
+	if err := recordJob(azcopyVersion, BackendAzBlob, blobAccountDefault, containerName); err != nil {
+		fmt.Println(err)
+	}
+	data = append(data, getContainerSAS(blobAccountDefault, svcClient, containerClient, time.Now(), time.Now().Add(hours*time.Hour), opts))
 	WriteToFile("locationB"+azcopyVersion+".csv", data)
 }
 
-func createLocationC(azcopyVersion string, containerName string, hours time.Duration) {
+func createLocationBDFS(azcopyVersion string, localPath string, hours time.Duration) {
+	svcClient, err := getDFSServiceClient(blobAccountDefault, nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	data := make([][]string, 0)
+	data = append(data, []string{localPath})
+	filesystemName := generateContainerName()
+	fsClient, err := createNewFilesystem(filesystemName, svcClient)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := recordJob(azcopyVersion, BackendAzDFS, blobAccountDefault, filesystemName); err != nil {
+		fmt.Println(err)
+	}
+	sasURLs, err := getFilesystemSAS(blobAccountDefault, svcClient, fsClient, time.Now(), time.Now().Add(hours*time.Hour))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	data = append(data, sasURLs)
+	WriteToFile("locationB"+azcopyVersion+".csv", data)
+}
+
+func createLocationC(azcopyVersion string, containerName string, hours time.Duration, lt locType, opts *sasOptions) {
+	if lt == locTypeDFS {
+		createLocationCDFS(azcopyVersion, containerName, hours)
+		return
+	}
 	svcClient1, err := getServiceClient(blobAccountDefault, nil)
 	if err != nil {
 		fmt.Println(err)
@@ -185,14 +260,61 @@ func createLocationC(azcopyVersion string, containerName string, hours time.Dura
 	}
 	data := make([][]string, 0)
 	containerClient1 := svcClient1.NewContainerClient(containerName)
-	data = append(data, getContainerSAS(blobAccountDefault, containerClient1, time.Now(), time.Now().Add(hours*time.Hour)))
+	data = append(data, getContainerSAS(blobAccountDefault, svcClient1, containerClient1, time.Now(), time.Now().Add(hours*time.Hour), opts))
+
+	containerName2 := generateContainerName()
+	containerClient2, err := createNewContainer(containerName2, svcClient2)
+	if err := recordJob(azcopyVersion, BackendAzBlob, blobAccountSecondary, containerName2); err != nil {
+		fmt.Println(err)
+	}
+	data = append(data, getContainerSAS(blobAccountSecondary, svcClient2, containerClient2, time.Now(), time.Now().Add(hours*time.Hour), opts))
+	WriteToFile("locationC"+azcopyVersion+".csv", data)
+}
+
+func createLocationCDFS(azcopyVersion string, filesystemName string, hours time.Duration) {
+	svcClient1, err := getDFSServiceClient(blobAccountDefault, nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 
-	containerClient2, err := createNewContainer(generateContainerName(), svcClient2)
-	data = append(data, getContainerSAS(blobAccountSecondary, containerClient2, time.Now(), time.Now().Add(hours*time.Hour)))
+	svcClient2, err := getDFSServiceClient(blobAccountSecondary, nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	data := make([][]string, 0)
+	fsClient1 := svcClient1.NewFileSystemClient(filesystemName)
+	sasURLs1, err := getFilesystemSAS(blobAccountDefault, svcClient1, fsClient1, time.Now(), time.Now().Add(hours*time.Hour))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	data = append(data, sasURLs1)
+
+	filesystemName2 := generateContainerName()
+	fsClient2, err := createNewFilesystem(filesystemName2, svcClient2)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := recordJob(azcopyVersion, BackendAzDFS, blobAccountSecondary, filesystemName2); err != nil {
+		fmt.Println(err)
+	}
+	sasURLs2, err := getFilesystemSAS(blobAccountSecondary, svcClient2, fsClient2, time.Now(), time.Now().Add(hours*time.Hour))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	data = append(data, sasURLs2)
 	WriteToFile("locationC"+azcopyVersion+".csv", data)
 }
 
-func createLocationD(azcopyVersion string, containerName string, hours time.Duration, localPath string) {
+func createLocationD(azcopyVersion string, containerName string, hours time.Duration, localPath string, lt locType, opts *sasOptions) {
+	if lt == locTypeDFS {
+		createLocationDDFS(azcopyVersion, containerName, hours, localPath)
+		return
+	}
 	svcClient, err := getServiceClient(blobAccountDefault, nil)
 	if err != nil {
 		fmt.Println(err)
@@ -200,13 +322,33 @@ func createLocationD(azcopyVersion string, containerName string, hours time.Dura
 	}
 	data := make([][]string, 0)
 	containerClient1 := svcClient.NewContainerClient(containerName)
-	data = append(data, getContainerSAS(blobAccountSecondary, containerClient1, time.Now(), time.Now().Add(hours*time.Hour)))
+	data = append(data, getContainerSAS(blobAccountSecondary, svcClient, containerClient1, time.Now(), time.Now().Add(hours*time.Hour), opts))
+	data = append(data, []string{localPath})
+	WriteToFile("locationD"+azcopyVersion+".csv", data)
+}
+
+func createLocationDDFS(azcopyVersion string, filesystemName string, hours time.Duration, localPath string) {
+	svcClient, err := getDFSServiceClient(blobAccountDefault, nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	data := make([][]string, 0)
+	fsClient1 := svcClient.NewFileSystemClient(filesystemName)
+	sasURLs, err := getFilesystemSAS(blobAccountSecondary, svcClient, fsClient1, time.Now(), time.Now().Add(hours*time.Hour))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	data = append(data, sasURLs)
 	data = append(data, []string{localPath})
 	WriteToFile("locationD"+azcopyVersion+".csv", data)
 }
 
+// getContainerName extracts the container/filesystem name from either a blob- or dfs-endpoint URL.
 func getContainerName(containerURL string) string {
-	urlParts := azblob.NewBlobURLParts(containerURL)
+	blobURL, _ := GetURLs(containerURL)
+	urlParts := azblob.NewBlobURLParts(blobURL)
 	return urlParts.ContainerName
 }
 
@@ -220,7 +362,7 @@ func publishResults(localPath, containerName string, hours time.Duration) {
 	data = append(data, []string{localPath + "/*.csv"})
 
 	containerClient1 := svcClient1.NewContainerClient(containerName)
-	data = append(data, getContainerSAS(blobAccountDefault, containerClient1, time.Now(), time.Now().Add(hours*time.Hour)))
+	data = append(data, getContainerSAS(blobAccountDefault, svcClient1, containerClient1, time.Now(), time.Now().Add(hours*time.Hour), nil))
 	WriteToFile("publishResultsLocation.csv", data)
 }
 
@@ -228,35 +370,115 @@ func main() {
 	// A (Local) --- upload ---> B (Container1) ---- S2S ---> C (container2) --- Download ---> D (Local)
 	// Create A by running local_file_generator.sh
 	// run "sh local_file_generator.sh"
-	arguments := os.Args[1:]
+	backend, arguments := extractBackendFlag(os.Args[1:])
+	sasOpts, arguments := parseSASFlags(arguments)
 	switch arguments[0] {
 	case "locB":
 		localPath := arguments[1]
 		sasValidityDuration, _ := strconv.Atoi(arguments[2])
 		azcopyVersion := arguments[3]
-		createLocationB(azcopyVersion, localPath, time.Duration(sasValidityDuration))
+		if backend != BackendAzBlob {
+			createLocationBBackend(azcopyVersion, localPath, time.Duration(sasValidityDuration), backend)
+			return
+		}
+		createLocationB(azcopyVersion, localPath, time.Duration(sasValidityDuration), locType(argOrEmpty(arguments, 4)), sasOpts)
+	case "locBVersion":
+		localPath := arguments[1]
+		sasValidityDuration, _ := strconv.Atoi(arguments[2])
+		azcopyVersion := arguments[3]
+		createLocationBVersion(azcopyVersion, localPath, time.Duration(sasValidityDuration), sasOpts)
 	case "locC":
-		containerName := getContainerName(arguments[1])
 		sasValidityDuration, _ := strconv.Atoi(arguments[2])
 		azcopyVersion := arguments[3]
-		createLocationC(azcopyVersion, containerName, time.Duration(sasValidityDuration))
-	case "locD":
+		if backend != BackendAzBlob {
+			createLocationCBackend(azcopyVersion, arguments[1], time.Duration(sasValidityDuration), backend)
+			return
+		}
 		containerName := getContainerName(arguments[1])
+		createLocationC(azcopyVersion, containerName, time.Duration(sasValidityDuration), locType(argOrEmpty(arguments, 4)), sasOpts)
+	case "locD":
 		sasValidityDuration, _ := strconv.Atoi(arguments[2])
 		localPath := arguments[3]
 		azcopyVersion := arguments[4]
-		createLocationD(azcopyVersion, containerName, time.Duration(sasValidityDuration), localPath)
+		if backend != BackendAzBlob {
+			createLocationDBackend(azcopyVersion, arguments[1], localPath)
+			return
+		}
+		containerName := getContainerName(arguments[1])
+		createLocationD(azcopyVersion, containerName, time.Duration(sasValidityDuration), localPath, locType(argOrEmpty(arguments, 5)), sasOpts)
 	case "delLocB":
+		if backend != BackendAzBlob {
+			b, err := getLocationBackend(backend, blobAccountDefault)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			name := b.Bind(arguments[1])
+			if err := b.Delete(); err != nil {
+				fmt.Println(err)
+			}
+			_ = markJobCompletedByContainer(name)
+			return
+		}
 		containerName := getContainerName(arguments[1])
-		deleteContainer(blobAccountDefault, containerName)
+		if locType(argOrEmpty(arguments, 2)) == locTypeDFS {
+			deleteFilesystem(blobAccountDefault, containerName)
+		} else {
+			deleteContainer(blobAccountDefault, containerName)
+		}
+		_ = markJobCompletedByContainer(containerName)
 	case "delLocC":
+		if backend != BackendAzBlob {
+			b, err := getLocationBackend(backend, blobAccountSecondary)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			name := b.Bind(arguments[1])
+			if err := b.Delete(); err != nil {
+				fmt.Println(err)
+			}
+			_ = markJobCompletedByContainer(name)
+			return
+		}
 		containerName := getContainerName(arguments[1])
-		deleteContainer(blobAccountSecondary, containerName)
+		if locType(argOrEmpty(arguments, 2)) == locTypeDFS {
+			deleteFilesystem(blobAccountSecondary, containerName)
+		} else {
+			deleteContainer(blobAccountSecondary, containerName)
+		}
+		_ = markJobCompletedByContainer(containerName)
 	case "pubRes":
 		localPath := arguments[1]
 		containerName := arguments[2]
 		sasValidityDuration, _ := strconv.Atoi(arguments[3])
 		publishResults(localPath, containerName, time.Duration(sasValidityDuration))
+	case "run":
+		if err := runScenario(arguments[1]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	case "list":
+		if err := listJobs(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	case "gc":
+		olderThan := 24 * time.Hour
+		if raw := argOrEmpty(arguments, 1); strings.HasPrefix(raw, "--older-than=") {
+			if d, err := time.ParseDuration(strings.TrimPrefix(raw, "--older-than=")); err == nil {
+				olderThan = d
+			}
+		}
+		if err := gcJobs(olderThan); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	case "resume":
+		if err := resumeJob(arguments[1]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	default:
 		fmt.Println("Incorrect argument " + arguments[0])
 	}
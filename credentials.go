@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+)
+
+//--------------------------------------Location: Auth ------------------------------------------------------------------
+
+// AuthTypeEnvVar selects which credential kind getServiceClient should mint for an account.
+// Per-account variants (e.g. SECONDARY_SYMPHONY_AUTH_TYPE) override this for that account type.
+const AuthTypeEnvVar = "SYMPHONY_AUTH_TYPE"
+
+const TenantIDEnvVar = "AZURE_TENANT_ID"
+const ClientIDEnvVar = "AZURE_CLIENT_ID"
+const ClientSecretEnvVar = "AZURE_CLIENT_SECRET"
+const ClientCertPathEnvVar = "AZURE_CLIENT_CERTIFICATE_PATH"
+
+type authType string
+
+const (
+	authSharedKey authType = "sharedkey"
+	authMSI       authType = "msi"
+	authSPN       authType = "spn"
+	authCLI       authType = "cli"
+	authDefault   authType = "default"
+)
+
+// getAuthType looks up the auth mode for an account, falling back to shared-key when unset so
+// existing invocations that only set AZURE_STORAGE_ACCOUNT_NAME/KEY keep working untouched.
+func getAuthType(accountType blobAccountType) authType {
+	if raw, ok := os.LookupEnv(string(accountType) + AuthTypeEnvVar); ok && raw != "" {
+		return authType(raw)
+	}
+	if raw, ok := os.LookupEnv(AuthTypeEnvVar); ok && raw != "" {
+		return authType(raw)
+	}
+	return authSharedKey
+}
+
+// getTokenCredential builds an azcore.TokenCredential for the non-shared-key auth modes.
+func getTokenCredential(accountType blobAccountType, auth authType) (azcore.TokenCredential, error) {
+	switch auth {
+	case authMSI:
+		opts := &azidentity.ManagedIdentityCredentialOptions{}
+		if clientID, ok := os.LookupEnv(string(accountType) + ClientIDEnvVar); ok && clientID != "" {
+			opts.ID = azidentity.ClientID(clientID)
+		}
+		return azidentity.NewManagedIdentityCredential(opts)
+	case authSPN:
+		tenantID, err := getRequiredEnv(string(accountType) + TenantIDEnvVar)
+		if err != nil {
+			return nil, err
+		}
+		clientID, err := getRequiredEnv(string(accountType) + ClientIDEnvVar)
+		if err != nil {
+			return nil, err
+		}
+		if certPath, ok := os.LookupEnv(string(accountType) + ClientCertPathEnvVar); ok && certPath != "" {
+			certData, err := os.ReadFile(certPath)
+			if err != nil {
+				return nil, err
+			}
+			certs, key, err := azidentity.ParseCertificates(certData, nil)
+			if err != nil {
+				return nil, err
+			}
+			return azidentity.NewClientCertificateCredential(tenantID, clientID, certs, key, nil)
+		}
+		clientSecret, err := getRequiredEnv(string(accountType) + ClientSecretEnvVar)
+		if err != nil {
+			return nil, err
+		}
+		return azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, nil)
+	case authCLI:
+		return azidentity.NewAzureCLICredential(nil)
+	case authDefault:
+		return azidentity.NewDefaultAzureCredential(nil)
+	default:
+		return nil, errors.New("unsupported auth type: " + string(auth))
+	}
+}
+
+// getUserDelegationCredential exchanges the service client's OAuth token for a delegation key
+// that can sign a user-delegation SAS, mirroring the shared-key SAS path for token-based accounts.
+func getUserDelegationCredential(serviceClient azblob.ServiceClient, start, expiry time.Time) (*azblob.UserDelegationCredential, error) {
+	udc, err := serviceClient.GetUserDelegationCredential(context.Background(), azblob.KeyInfo{
+		Start:  to.Ptr(start.UTC().Format(azblob.SASTimeFormat)),
+		Expiry: to.Ptr(expiry.UTC().Format(azblob.SASTimeFormat)),
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return udc, nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+//--------------------------------------Job store -----------------------------------------------------------------
+
+// jobContainer is one container/filesystem created for a job, along with the backend and account
+// it lives in - gcJobs needs both to tear it down against the right account.
+type jobContainer struct {
+	Name        string          `json:"name"`
+	Backend     BackendType     `json:"backend"`
+	AccountType blobAccountType `json:"accountType"`
+}
+
+// jobRecord tracks the containers a single invocation created, so a crashed pipeline doesn't leave
+// orphaned containers in the account with no record of them.
+type jobRecord struct {
+	ID         string         `json:"id"` // azcopyVersion, or the scenario name for `run`
+	Containers []jobContainer `json:"containers"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	Completed  bool           `json:"completed"`
+}
+
+type jobJournal struct {
+	Jobs []jobRecord `json:"jobs"`
+}
+
+const journalPath = "symphony-journal.json"
+
+func loadJournal() (*jobJournal, error) {
+	raw, err := os.ReadFile(journalPath)
+	if os.IsNotExist(err) {
+		return &jobJournal{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var j jobJournal
+	if err := json.Unmarshal(raw, &j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func saveJournal(j *jobJournal) error {
+	raw, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(journalPath, raw, 0644)
+}
+
+// recordJob appends a job, or merges its containers into an existing job with the same ID - locB
+// and locC of the same azcopyVersion both contribute containers to one journal entry, each keeping
+// its own backend/accountType since locC's destination is typically a different account than locB's.
+func recordJob(id string, backend BackendType, accountType blobAccountType, containerNames ...string) error {
+	j, err := loadJournal()
+	if err != nil {
+		return err
+	}
+	containers := make([]jobContainer, 0, len(containerNames))
+	for _, name := range containerNames {
+		containers = append(containers, jobContainer{Name: name, Backend: backend, AccountType: accountType})
+	}
+	for i := range j.Jobs {
+		if j.Jobs[i].ID == id {
+			j.Jobs[i].Containers = append(j.Jobs[i].Containers, containers...)
+			return saveJournal(j)
+		}
+	}
+	j.Jobs = append(j.Jobs, jobRecord{
+		ID:         id,
+		Containers: containers,
+		CreatedAt:  time.Now(),
+	})
+	return saveJournal(j)
+}
+
+func markJobCompleted(id string) error {
+	j, err := loadJournal()
+	if err != nil {
+		return err
+	}
+	for i := range j.Jobs {
+		if j.Jobs[i].ID == id {
+			j.Jobs[i].Completed = true
+		}
+	}
+	return saveJournal(j)
+}
+
+// markJobCompletedByContainer removes containerName from whichever job owns it, and only flags
+// that job Completed once every container it created has been torn down - delLocB tearing down
+// its container must not make delLocC's still-live container unreclaimable by gcJobs.
+func markJobCompletedByContainer(containerName string) error {
+	j, err := loadJournal()
+	if err != nil {
+		return err
+	}
+	for i := range j.Jobs {
+		remaining := j.Jobs[i].Containers[:0]
+		for _, c := range j.Jobs[i].Containers {
+			if c.Name != containerName {
+				remaining = append(remaining, c)
+			}
+		}
+		j.Jobs[i].Containers = remaining
+		if len(remaining) == 0 {
+			j.Jobs[i].Completed = true
+		}
+	}
+	return saveJournal(j)
+}
+
+func findJob(id string) (*jobRecord, error) {
+	j, err := loadJournal()
+	if err != nil {
+		return nil, err
+	}
+	for i := range j.Jobs {
+		if j.Jobs[i].ID == id {
+			return &j.Jobs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no job found with id %s", id)
+}
+
+// listJobs implements `symphony list`.
+func listJobs() error {
+	j, err := loadJournal()
+	if err != nil {
+		return err
+	}
+	for _, job := range j.Jobs {
+		status := "in-progress"
+		if job.Completed {
+			status = "completed"
+		}
+		fmt.Printf("%s\t%s\t%s\t%v\n", job.ID, status, job.CreatedAt.Format(time.RFC3339), job.Containers)
+	}
+	return nil
+}
+
+// gcJobs implements `symphony gc --older-than`: any job that never completed and is older than
+// olderThan gets its containers deleted (each against its own recorded backend/accountType) and is
+// dropped from the journal.
+func gcJobs(olderThan time.Duration) error {
+	j, err := loadJournal()
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	remaining := make([]jobRecord, 0, len(j.Jobs))
+	for _, job := range j.Jobs {
+		if !job.Completed && job.CreatedAt.Before(cutoff) {
+			for _, c := range job.Containers {
+				switch c.Backend {
+				case "", BackendAzBlob:
+					deleteContainer(c.AccountType, c.Name)
+				case BackendAzDFS:
+					deleteFilesystem(c.AccountType, c.Name)
+				default:
+					fmt.Printf("skipping gc of %s container %s: only azblob/azdfs cleanup is automatic\n", c.Backend, c.Name)
+				}
+			}
+			continue
+		}
+		remaining = append(remaining, job)
+	}
+	j.Jobs = remaining
+	return saveJournal(j)
+}
+
+// resumeJob implements `symphony resume <id>`, looking up the containers recorded for a prior run
+// and printing them with the job's completion state. It does not re-run or tear down anything, and
+// delLocB/delLocC still take the container URL as an argument.
+func resumeJob(id string) error {
+	job, err := findJob(id)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("job %s: containers=%v completed=%v\n", job.ID, job.Containers, job.Completed)
+	return nil
+}
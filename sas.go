@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+)
+
+//--------------------------------------SAS options --------------------------------------------------------------------
+
+// sasOptions narrows the SAS signed to a location below the "everything, forever" default that
+// getContainerSAS used to hand out unconditionally, so least-privilege azcopy flows can be exercised.
+type sasOptions struct {
+	Permissions string // letters from "racwdlt", e.g. "rl", "rwdl", "racwdl", "racwdlt"
+	IPRange     string // single IP or "start-end"
+	HTTPSOnly   bool
+}
+
+// parseContainerPermissions turns a permission string like "racwdl" into the SDK's permission
+// struct; unrecognized letters are ignored so a typo degrades to fewer permissions, not a panic.
+func parseContainerPermissions(perm string) azblob.ContainerSASPermissions {
+	var p azblob.ContainerSASPermissions
+	for _, c := range perm {
+		switch c {
+		case 'r':
+			p.Read = true
+		case 'a':
+			p.Add = true
+		case 'c':
+			p.Create = true
+		case 'w':
+			p.Write = true
+		case 'd':
+			p.Delete = true
+		case 'l':
+			p.List = true
+		case 't':
+			p.Tag = true
+		}
+	}
+	return p
+}
+
+func defaultContainerPermissions() azblob.ContainerSASPermissions {
+	return azblob.ContainerSASPermissions{Read: true, Add: true, Create: true, Write: true, Delete: true, List: true}
+}
+
+func (o *sasOptions) containerPermissions() azblob.ContainerSASPermissions {
+	if o == nil || o.Permissions == "" {
+		return defaultContainerPermissions()
+	}
+	return parseContainerPermissions(o.Permissions)
+}
+
+// parseBlobPermissions is parseContainerPermissions for a single blob (or blob version): it covers
+// the same CRUD letters shared with ContainerSASPermissions. Snapshot/version-specific bits (e.g.
+// permanent delete of a prior version) aren't exposed here - createLocationBVersion only needs
+// read access to exercise the versioned-blob traverser, so that gap is left for a future request.
+func parseBlobPermissions(perm string) azblob.BlobSASPermissions {
+	var p azblob.BlobSASPermissions
+	for _, c := range perm {
+		switch c {
+		case 'r':
+			p.Read = true
+		case 'a':
+			p.Add = true
+		case 'c':
+			p.Create = true
+		case 'w':
+			p.Write = true
+		case 'd':
+			p.Delete = true
+		case 'l':
+			p.List = true
+		case 't':
+			p.Tag = true
+		}
+	}
+	return p
+}
+
+func (o *sasOptions) blobPermissions() azblob.BlobSASPermissions {
+	if o == nil || o.Permissions == "" {
+		return azblob.BlobSASPermissions{Read: true}
+	}
+	return parseBlobPermissions(o.Permissions)
+}
+
+func (o *sasOptions) applyTo(values azblob.BlobSASSignatureValues) azblob.BlobSASSignatureValues {
+	if o == nil {
+		return values
+	}
+	if o.IPRange != "" {
+		parts := strings.SplitN(o.IPRange, "-", 2)
+		values.IPRange.Start = net.ParseIP(parts[0])
+		if len(parts) == 2 {
+			values.IPRange.End = net.ParseIP(parts[1])
+		}
+	}
+	if o.HTTPSOnly {
+		values.Protocol = azblob.SASProtocolHTTPS
+	}
+	return values
+}
+
+// parseSASFlags pulls "--perms=", "--ip=" and "--https" out of the CLI arguments wherever they
+// appear, the same way extractBackendFlag does for "--backend=".
+func parseSASFlags(arguments []string) (*sasOptions, []string) {
+	opts := &sasOptions{}
+	remaining := make([]string, 0, len(arguments))
+	for _, a := range arguments {
+		switch {
+		case strings.HasPrefix(a, "--perms="):
+			opts.Permissions = strings.TrimPrefix(a, "--perms=")
+		case strings.HasPrefix(a, "--ip="):
+			opts.IPRange = strings.TrimPrefix(a, "--ip=")
+		case a == "--https":
+			opts.HTTPSOnly = true
+		default:
+			remaining = append(remaining, a)
+		}
+	}
+	return opts, remaining
+}
+
+// createLocationBVersion creates a container, uploads a placeholder blob, and emits a SAS scoped
+// to that blob's version so azcopy's versioned-blob traverser can be exercised end-to-end. When the
+// upload returns no version ID (versioning off on the account), the SAS is scoped to the blob itself.
+func createLocationBVersion(azcopyVersion string, localPath string, hours time.Duration, opts *sasOptions) {
+	svcClient, err := getServiceClient(blobAccountDefault, nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	data := make([][]string, 0)
+	data = append(data, []string{localPath})
+
+	containerName := generateContainerName()
+	containerClient, err := createNewContainer(containerName, svcClient)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := recordJob(azcopyVersion, BackendAzBlob, blobAccountDefault, containerName); err != nil {
+		fmt.Println(err)
+	}
+
+	blobClient := containerClient.NewBlockBlobClient("symphony-placeholder")
+	uploadResp, err := blobClient.UploadBuffer(context.Background(), []byte("symphony-placeholder"), nil)
+	if err != nil {
+		_, _ = containerClient.Delete(context.Background(), nil)
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	urlParts := azblob.NewBlobURLParts(blobClient.URL())
+	if uploadResp.VersionID != nil {
+		urlParts.VersionID = *uploadResp.VersionID
+	}
+
+	start, expiry := time.Now(), time.Now().Add(hours*time.Hour)
+	sasValues := opts.applyTo(azblob.BlobSASSignatureValues{
+		ContainerName: urlParts.ContainerName,
+		BlobName:      urlParts.BlobName,
+		BlobVersion:   urlParts.VersionID,
+		Permissions:   opts.blobPermissions().String(),
+		StartTime:     start.UTC(),
+		ExpiryTime:    expiry.UTC(),
+	})
+
+	var sasToken string
+	if auth := getAuthType(blobAccountDefault); auth == authSharedKey {
+		var credentials *azblob.SharedKeyCredential
+		credentials, err = getGenericCredential(blobAccountDefault)
+		if err == nil {
+			sasToken, err = sasValues.NewSASQueryParameters(credentials)
+		}
+	} else {
+		var udc *azblob.UserDelegationCredential
+		udc, err = getUserDelegationCredential(svcClient, start, expiry)
+		if err == nil {
+			sasToken, err = sasValues.SignWithUserDelegation(udc)
+		}
+	}
+	if err != nil {
+		_, _ = containerClient.Delete(context.Background(), nil)
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	urlParts.SAS = sasToken
+	data = append(data, []string{urlParts.URL()})
+	WriteToFile("locationB"+azcopyVersion+".csv", data)
+}
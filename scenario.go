@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//--------------------------------------Scenario runner ----------------------------------------------------------------
+
+// scenarioNode declares one location in the benchmark graph (e.g. B, C, D) and how to materialize it.
+type scenarioNode struct {
+	Name           string          `yaml:"name"`
+	Backend        BackendType     `yaml:"backend"`
+	LocType        locType         `yaml:"locType,omitempty"` // parsed but not read by runScenario; nodes are materialized by Backend alone
+	AccountType    blobAccountType `yaml:"accountType,omitempty"`
+	ContainerName  string          `yaml:"containerName,omitempty"`
+	NamingStrategy string          `yaml:"namingStrategy,omitempty"` // random (default) | fixed | prefix
+	SASValidity    string          `yaml:"sasValidity,omitempty"`    // Go duration string, e.g. "1h"; defaults to 1h
+	Permissions    string          `yaml:"permissions,omitempty"`
+	IPRange        string          `yaml:"ipRange,omitempty"` // single IP or "start-end", see sasOptions.IPRange
+	HTTPSOnly      bool            `yaml:"httpsOnly,omitempty"`
+	Cleanup        bool            `yaml:"cleanup,omitempty"`
+}
+
+// scenarioCommand is one azcopy invocation to run once every node above has a signed URL; `Run`
+// may reference a node's URL as {{.NodeName}}.
+type scenarioCommand struct {
+	Run string `yaml:"run"`
+}
+
+type scenario struct {
+	Name      string            `yaml:"name"`
+	Nodes     []scenarioNode    `yaml:"nodes"`
+	Commands  []scenarioCommand `yaml:"commands"`
+	PublishTo string            `yaml:"publishTo,omitempty"`
+}
+
+func loadScenario(path string) (*scenario, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s scenario
+	if err := yaml.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func containerNameFor(node scenarioNode) string {
+	switch node.NamingStrategy {
+	case "fixed":
+		return node.ContainerName
+	case "prefix":
+		return node.ContainerName + generateContainerName()
+	default:
+		return generateContainerName()
+	}
+}
+
+// runScenario materializes each node in order, threads its signed URL into later command
+// templates, and tears down every node marked Cleanup on completion or on failure.
+func runScenario(path string) error {
+	s, err := loadScenario(path)
+	if err != nil {
+		return err
+	}
+
+	urls := make(map[string]string, len(s.Nodes))
+	toCleanUp := make(map[string]LocationBackend, len(s.Nodes))
+	defer func() {
+		for name, b := range toCleanUp {
+			if err := b.Delete(); err != nil {
+				fmt.Printf("Failed to clean up node %s: %s\n", name, err.Error())
+			}
+		}
+	}()
+
+	for _, node := range s.Nodes {
+		b, err := getLocationBackend(node.Backend, node.AccountType)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", node.Name, err)
+		}
+
+		containerName, err := b.CreateContainer(containerNameFor(node))
+		if err != nil {
+			return fmt.Errorf("node %s: %w", node.Name, err)
+		}
+		if err := recordJob(s.Name, node.Backend, node.AccountType, containerName); err != nil {
+			fmt.Println(err)
+		}
+		if node.Cleanup {
+			toCleanUp[node.Name] = b
+		}
+
+		validity := time.Hour
+		if node.SASValidity != "" {
+			validity, err = time.ParseDuration(node.SASValidity)
+			if err != nil {
+				return fmt.Errorf("node %s: invalid sasValidity %q: %w", node.Name, node.SASValidity, err)
+			}
+		}
+		opts := &sasOptions{Permissions: node.Permissions, IPRange: node.IPRange, HTTPSOnly: node.HTTPSOnly}
+		signedURL, err := b.SignedURL(time.Now(), time.Now().Add(validity), opts)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", node.Name, err)
+		}
+		urls[node.Name] = signedURL
+	}
+
+	for _, cmd := range s.Commands {
+		line := cmd.Run
+		for name, url := range urls {
+			line = strings.ReplaceAll(line, "{{."+name+"}}", url)
+		}
+		fmt.Println("running:", line)
+		parts := strings.Fields(line)
+		if len(parts) == 0 {
+			continue
+		}
+		execCmd := exec.Command(parts[0], parts[1:]...)
+		execCmd.Stdout = os.Stdout
+		execCmd.Stderr = os.Stderr
+		if err := execCmd.Run(); err != nil {
+			return fmt.Errorf("command %q: %w", cmd.Run, err)
+		}
+	}
+
+	if s.PublishTo != "" {
+		publishResults(".", s.PublishTo, time.Duration(1))
+	}
+	if err := markJobCompleted(s.Name); err != nil {
+		fmt.Println(err)
+	}
+	return nil
+}